@@ -0,0 +1,147 @@
+package dockerhelper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+)
+
+// AuthResolver resolves the Docker registry credentials to use when pulling
+// an image from the given registry host (e.g. "docker.io", "registry.example.com:5000").
+// Implementations should return a zero-value docker.AuthConfiguration and a
+// nil error when no credentials are available for the registry, rather than
+// treating the absence of credentials as a failure.
+type AuthResolver interface {
+	ResolveAuthentication(registry string) (docker.AuthConfiguration, error)
+}
+
+// AuthResolverFunc adapts a function to an AuthResolver.
+type AuthResolverFunc func(registry string) (docker.AuthConfiguration, error)
+
+// ResolveAuthentication implements AuthResolver.
+func (f AuthResolverFunc) ResolveAuthentication(registry string) (docker.AuthConfiguration, error) {
+	return f(registry)
+}
+
+// MultiAuthResolver tries each resolver in order, returning the first
+// non-empty AuthConfiguration found. Resolvers that return an error stop the
+// search and propagate the error.
+type MultiAuthResolver []AuthResolver
+
+// ResolveAuthentication implements AuthResolver.
+func (m MultiAuthResolver) ResolveAuthentication(registry string) (docker.AuthConfiguration, error) {
+	for _, resolver := range m {
+		auth, err := resolver.ResolveAuthentication(registry)
+		if err != nil {
+			return docker.AuthConfiguration{}, err
+		}
+		if auth != (docker.AuthConfiguration{}) {
+			return auth, nil
+		}
+	}
+	return docker.AuthConfiguration{}, nil
+}
+
+// dockerConfigEntry mirrors the per-registry entry found in a Docker
+// config.json file.
+type dockerConfigEntry struct {
+	Auth  string `json:"auth"`
+	Email string `json:"email"`
+}
+
+// NewDockerConfigAuthResolver returns an AuthResolver that reads credentials
+// from a Docker config.json file, as written by `docker login`. If path is
+// empty, ~/.docker/config.json is used. A missing file is not an error; it
+// simply yields no credentials.
+func NewDockerConfigAuthResolver(path string) AuthResolver {
+	return AuthResolverFunc(func(registry string) (docker.AuthConfiguration, error) {
+		configPath := path
+		if len(configPath) == 0 {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return docker.AuthConfiguration{}, err
+			}
+			configPath = filepath.Join(home, ".docker", "config.json")
+		}
+		data, err := os.ReadFile(configPath)
+		if os.IsNotExist(err) {
+			return docker.AuthConfiguration{}, nil
+		}
+		if err != nil {
+			return docker.AuthConfiguration{}, err
+		}
+		return authFromDockerConfigJSON(data, registry)
+	})
+}
+
+func authFromDockerConfigJSON(data []byte, registry string) (docker.AuthConfiguration, error) {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return docker.AuthConfiguration{}, fmt.Errorf("error parsing docker config: %v", err)
+	}
+	entries := map[string]dockerConfigEntry{}
+	if auths, ok := raw["auths"]; ok {
+		if err := json.Unmarshal(auths, &entries); err != nil {
+			return docker.AuthConfiguration{}, fmt.Errorf("error parsing docker config auths: %v", err)
+		}
+	} else {
+		// .dockercfg style file: entries live at the top level.
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return docker.AuthConfiguration{}, fmt.Errorf("error parsing docker config: %v", err)
+		}
+	}
+	entry, ok := entries[registry]
+	if !ok {
+		glog.V(5).Infof("No docker config entry found for registry %q", registry)
+		return docker.AuthConfiguration{}, nil
+	}
+	return decodeAuthEntry(entry, registry)
+}
+
+func decodeAuthEntry(entry dockerConfigEntry, registry string) (docker.AuthConfiguration, error) {
+	auth := docker.AuthConfiguration{
+		Email:         entry.Email,
+		ServerAddress: registry,
+	}
+	if len(entry.Auth) == 0 {
+		return auth, nil
+	}
+	decoded, err := decodeBase64UserPass(entry.Auth)
+	if err != nil {
+		return docker.AuthConfiguration{}, fmt.Errorf("error decoding auth for registry %q: %v", registry, err)
+	}
+	auth.Username, auth.Password = decoded[0], decoded[1]
+	return auth, nil
+}
+
+func decodeBase64UserPass(encoded string) ([2]string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return [2]string{}, err
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return [2]string{}, fmt.Errorf("invalid auth string: expected \"user:password\"")
+	}
+	return [2]string{parts[0], parts[1]}, nil
+}
+
+// NewKubernetesPullSecretAuthResolver returns an AuthResolver backed by the
+// contents of a Kubernetes pull secret, in either ".dockercfg" or
+// ".dockerconfigjson" form. Callers typically extract this data themselves
+// (e.g. via a Secret's Data[".dockercfg"] or Data[".dockerconfigjson"] key)
+// since this package does not depend on the Kubernetes client libraries.
+func NewKubernetesPullSecretAuthResolver(secretData []byte) AuthResolver {
+	return AuthResolverFunc(func(registry string) (docker.AuthConfiguration, error) {
+		if len(secretData) == 0 {
+			return docker.AuthConfiguration{}, nil
+		}
+		return authFromDockerConfigJSON(secretData, registry)
+	})
+}
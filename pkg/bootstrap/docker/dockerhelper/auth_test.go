@@ -0,0 +1,104 @@
+package dockerhelper
+
+import (
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func TestAuthFromDockerConfigJSON(t *testing.T) {
+	data := []byte(`{
+		"auths": {
+			"registry.example.com": {
+				"auth": "dXNlcjpwYXNz",
+				"email": "user@example.com"
+			}
+		}
+	}`)
+
+	auth, err := authFromDockerConfigJSON(data, "registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := docker.AuthConfiguration{
+		Username:      "user",
+		Password:      "pass",
+		Email:         "user@example.com",
+		ServerAddress: "registry.example.com",
+	}
+	if auth != expected {
+		t.Errorf("authFromDockerConfigJSON() = %+v, want %+v", auth, expected)
+	}
+
+	auth, err = authFromDockerConfigJSON(data, "other.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth != (docker.AuthConfiguration{}) {
+		t.Errorf("expected empty AuthConfiguration for unknown registry, got %+v", auth)
+	}
+}
+
+func TestAuthFromDockerConfigJSONLegacyFormat(t *testing.T) {
+	data := []byte(`{
+		"registry.example.com": {
+			"auth": "dXNlcjpwYXNz",
+			"email": "user@example.com"
+		}
+	}`)
+
+	auth, err := authFromDockerConfigJSON(data, "registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth.Username != "user" || auth.Password != "pass" {
+		t.Errorf("authFromDockerConfigJSON() = %+v, want username=user password=pass", auth)
+	}
+}
+
+func TestDecodeBase64UserPass(t *testing.T) {
+	decoded, err := decodeBase64UserPass("dXNlcjpwYXNz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded[0] != "user" || decoded[1] != "pass" {
+		t.Errorf("decodeBase64UserPass() = %v, want [user pass]", decoded)
+	}
+
+	if _, err := decodeBase64UserPass("bm8tY29sb24="); err == nil {
+		t.Errorf("expected error decoding auth string without a colon")
+	}
+
+	if _, err := decodeBase64UserPass("not-base64!!"); err == nil {
+		t.Errorf("expected error decoding invalid base64")
+	}
+}
+
+func TestMultiAuthResolver(t *testing.T) {
+	empty := AuthResolverFunc(func(string) (docker.AuthConfiguration, error) {
+		return docker.AuthConfiguration{}, nil
+	})
+	found := AuthResolverFunc(func(string) (docker.AuthConfiguration, error) {
+		return docker.AuthConfiguration{Username: "found"}, nil
+	})
+
+	resolver := MultiAuthResolver{empty, found}
+	auth, err := resolver.ResolveAuthentication("registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth.Username != "found" {
+		t.Errorf("MultiAuthResolver.ResolveAuthentication() = %+v, want username=found", auth)
+	}
+}
+
+func TestKubernetesPullSecretAuthResolverEmptySecret(t *testing.T) {
+	resolver := NewKubernetesPullSecretAuthResolver(nil)
+	auth, err := resolver.ResolveAuthentication("registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth != (docker.AuthConfiguration{}) {
+		t.Errorf("expected empty AuthConfiguration for nil secret data, got %+v", auth)
+	}
+}
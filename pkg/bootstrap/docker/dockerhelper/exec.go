@@ -0,0 +1,101 @@
+package dockerhelper
+
+import (
+	"io"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+
+	starterrors "github.com/openshift/origin/pkg/bootstrap/docker/errors"
+)
+
+// Exec runs cmd inside the running container id, attaching stdin (if
+// non-nil) and streaming stdout/stderr, and returns the command's exit code.
+func (h *Helper) Exec(id string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	glog.V(5).Infof("Creating exec for container %q: %v", id, cmd)
+	exec, err := h.client.CreateExec(docker.CreateExecOptions{
+		Container:    id,
+		Cmd:          cmd,
+		AttachStdin:  stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return 0, starterrors.NewError("cannot create exec for container %s", id).WithCause(err)
+	}
+
+	glog.V(5).Infof("Starting exec %q in container %q", exec.ID, id)
+	err = h.client.StartExec(exec.ID, docker.StartExecOptions{
+		InputStream:  stdin,
+		OutputStream: stdout,
+		ErrorStream:  stderr,
+	})
+	if err != nil {
+		return 0, starterrors.NewError("cannot start exec for container %s", id).WithCause(err)
+	}
+
+	inspect, err := h.client.InspectExec(exec.ID)
+	if err != nil {
+		return 0, starterrors.NewError("cannot inspect exec for container %s", id).WithCause(err)
+	}
+	glog.V(5).Infof("Exec %q in container %q exited with code %d", exec.ID, id, inspect.ExitCode)
+	return inspect.ExitCode, nil
+}
+
+// LogOptions controls StreamLogs.
+type LogOptions struct {
+	// Follow keeps the stream open and continues to write new log lines
+	// as they are produced.
+	Follow bool
+	// Since, if non-zero, restricts output to log lines at or after this
+	// time. It is converted to the Unix timestamp the Docker API expects.
+	Since time.Time
+	// Tail, if non-empty, restricts output to the last N lines, or "all".
+	Tail string
+	// Timestamps prefixes each line with its timestamp.
+	Timestamps bool
+}
+
+// sinceUnix converts t to the Unix timestamp docker.LogsOptions.Since
+// expects, or 0 (meaning "no lower bound") for the zero time.
+func sinceUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// StreamLogs streams the logs of container id to stdout/stderr according to
+// opts, blocking until the stream ends (or, with Follow set, until the
+// container stops producing logs).
+func (h *Helper) StreamLogs(id string, opts LogOptions, stdout, stderr io.Writer) error {
+	glog.V(5).Infof("Streaming logs for container %q: %+v", id, opts)
+	err := h.client.Logs(docker.LogsOptions{
+		Container:    id,
+		OutputStream: stdout,
+		ErrorStream:  stderr,
+		Stdout:       true,
+		Stderr:       true,
+		Follow:       opts.Follow,
+		Since:        sinceUnix(opts.Since),
+		Tail:         opts.Tail,
+		Timestamps:   opts.Timestamps,
+	})
+	if err != nil {
+		return starterrors.NewError("cannot stream logs for container %s", id).WithCause(err)
+	}
+	return nil
+}
+
+// WaitContainer blocks until container id stops running and returns its
+// exit code.
+func (h *Helper) WaitContainer(id string) (int, error) {
+	glog.V(5).Infof("Waiting for container %q to exit", id)
+	code, err := h.client.WaitContainer(id)
+	if err != nil {
+		return 0, starterrors.NewError("error waiting for container %s", id).WithCause(err)
+	}
+	glog.V(5).Infof("Container %q exited with code %d", id, code)
+	return code, nil
+}
@@ -0,0 +1,17 @@
+package dockerhelper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSinceUnix(t *testing.T) {
+	if got := sinceUnix(time.Time{}); got != 0 {
+		t.Errorf("sinceUnix(zero time) = %d, want 0", got)
+	}
+
+	at := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := sinceUnix(at); got != at.Unix() {
+		t.Errorf("sinceUnix(%v) = %d, want %d", at, got, at.Unix())
+	}
+}
@@ -0,0 +1,185 @@
+package dockerhelper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+
+	starterrors "github.com/openshift/origin/pkg/bootstrap/docker/errors"
+)
+
+// ProbeType identifies the mechanism a Probe uses to check readiness.
+type ProbeType string
+
+const (
+	ProbeTypeTCP  ProbeType = "TCP"
+	ProbeTypeHTTP ProbeType = "HTTP"
+	ProbeTypeExec ProbeType = "Exec"
+)
+
+// Probe describes how to check whether a container is ready to serve
+// requests, modeled after the Kubernetes readiness probe types.
+type Probe struct {
+	Type ProbeType
+
+	// TCP and HTTP probes dial this address (host:port).
+	Address string
+
+	// HTTP probes issue a GET to this path and consider any status in
+	// HTTPExpectedStatus (or 200-399 if empty) a success.
+	HTTPPath           string
+	HTTPExpectedStatus []int
+
+	// Exec probes run Cmd inside ContainerID via Helper.Exec and consider
+	// exit code 0 a success.
+	ContainerID string
+	Cmd         []string
+}
+
+// ProbeErrorReason distinguishes why WaitForHealthy failed, so callers can
+// react differently (e.g. retry vs. surface a crash loop to the user).
+type ProbeErrorReason string
+
+const (
+	// ProbeReasonContainerExited means the container stopped running
+	// before the probe ever succeeded.
+	ProbeReasonContainerExited ProbeErrorReason = "ContainerExited"
+	// ProbeReasonTimeout means the timeout elapsed without a successful probe
+	// and the last attempt did not get as far as a status (e.g. connection
+	// refused).
+	ProbeReasonTimeout ProbeErrorReason = "Timeout"
+	// ProbeReasonBadStatus means the timeout elapsed and the last probe ran
+	// but reported the container unready (e.g. an HTTP status outside the
+	// expected set, non-zero exec exit code).
+	ProbeReasonBadStatus ProbeErrorReason = "BadStatus"
+)
+
+// ProbeError is returned by WaitForHealthy when the container never becomes
+// healthy, with Reason distinguishing the kind of failure.
+type ProbeError struct {
+	Reason ProbeErrorReason
+	Cause  error
+}
+
+func (e *ProbeError) Error() string {
+	if e.Cause == nil {
+		return string(e.Reason)
+	}
+	return fmt.Sprintf("%s: %v", e.Reason, e.Cause)
+}
+
+const (
+	defaultProbeBackoff    = 100 * time.Millisecond
+	defaultProbeMaxBackoff = 5 * time.Second
+)
+
+// WaitForHealthy polls container id until probe reports it ready, the
+// container exits, or timeout elapses, backing off exponentially between
+// probe attempts (starting at 100ms, capped at 5s). A probe attempt is
+// always made at (or just short of) the deadline before giving up, rather
+// than bailing out early because the next backoff step would overshoot it.
+func (h *Helper) WaitForHealthy(id string, probe Probe, timeout time.Duration) error {
+	return waitForHealthy(
+		id,
+		func() (bool, bool, error) { return h.GetContainerState(id) },
+		func() (bool, error) { return h.runProbe(probe) },
+		timeout,
+		defaultProbeBackoff,
+		defaultProbeMaxBackoff,
+	)
+}
+
+// waitForHealthy implements the polling/backoff state machine behind
+// WaitForHealthy, with checkState and probeOnce as seams so the state
+// machine itself can be unit tested without a real Docker daemon.
+func waitForHealthy(id string, checkState func() (exists, running bool, err error), probeOnce func() (bool, error), timeout, backoff, maxBackoff time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		exists, running, err := checkState()
+		if err != nil {
+			return starterrors.NewError("error inspecting container %s", id).WithCause(err)
+		}
+		if !exists || !running {
+			return &ProbeError{Reason: ProbeReasonContainerExited}
+		}
+
+		ready, err := probeOnce()
+		if ready {
+			return nil
+		}
+		glog.V(5).Infof("Probe for container %q not yet ready: %v", id, err)
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			if probeErr, ok := err.(*ProbeError); ok && probeErr.Reason == ProbeReasonBadStatus {
+				return probeErr
+			}
+			return &ProbeError{Reason: ProbeReasonTimeout, Cause: err}
+		}
+		if backoff > remaining {
+			backoff = remaining
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// runProbe executes probe once, returning whether it succeeded and, if not,
+// an error describing why (wrapped as *ProbeError with ProbeReasonBadStatus
+// when the probe ran to completion but reported the container unready).
+func (h *Helper) runProbe(probe Probe) (bool, error) {
+	switch probe.Type {
+	case ProbeTypeTCP:
+		conn, err := net.DialTimeout("tcp", probe.Address, 2*time.Second)
+		if err != nil {
+			return false, err
+		}
+		conn.Close()
+		return true, nil
+
+	case ProbeTypeHTTP:
+		client := http.Client{Timeout: 2 * time.Second}
+		resp, err := client.Get(fmt.Sprintf("http://%s%s", probe.Address, probe.HTTPPath))
+		if err != nil {
+			return false, err
+		}
+		defer resp.Body.Close()
+		if httpStatusOK(resp.StatusCode, probe.HTTPExpectedStatus) {
+			return true, nil
+		}
+		return false, &ProbeError{Reason: ProbeReasonBadStatus, Cause: fmt.Errorf("unexpected status %d", resp.StatusCode)}
+
+	case ProbeTypeExec:
+		code, err := h.Exec(probe.ContainerID, probe.Cmd, nil, ioutil.Discard, ioutil.Discard)
+		if err != nil {
+			return false, err
+		}
+		if code != 0 {
+			return false, &ProbeError{Reason: ProbeReasonBadStatus, Cause: fmt.Errorf("exec exited with code %d", code)}
+		}
+		return true, nil
+
+	default:
+		return false, fmt.Errorf("unknown probe type %q", probe.Type)
+	}
+}
+
+func httpStatusOK(status int, expected []int) bool {
+	if len(expected) == 0 {
+		return status >= 200 && status < 400
+	}
+	for _, s := range expected {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package dockerhelper
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPStatusOK(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected []int
+		want     bool
+	}{
+		{200, nil, true},
+		{399, nil, true},
+		{400, nil, false},
+		{404, []int{200, 404}, true},
+		{500, []int{200, 404}, false},
+	}
+	for _, test := range tests {
+		if got := httpStatusOK(test.status, test.expected); got != test.want {
+			t.Errorf("httpStatusOK(%d, %v) = %v, want %v", test.status, test.expected, got, test.want)
+		}
+	}
+}
+
+func TestRunProbeTCPSuccess(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	h := &Helper{}
+	ok, err := h.runProbe(Probe{Type: ProbeTypeTCP, Address: listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Errorf("expected TCP probe against a listening port to succeed")
+	}
+}
+
+func TestRunProbeTCPFailure(t *testing.T) {
+	h := &Helper{}
+	ok, err := h.runProbe(Probe{Type: ProbeTypeTCP, Address: "127.0.0.1:1"})
+	if ok {
+		t.Errorf("expected TCP probe against a closed port to fail")
+	}
+	if err == nil {
+		t.Errorf("expected an error for a failed TCP probe")
+	}
+}
+
+func TestRunProbeHTTPSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	h := &Helper{}
+	ok, err := h.runProbe(Probe{Type: ProbeTypeHTTP, Address: strings.TrimPrefix(server.URL, "http://"), HTTPPath: "/healthz"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Errorf("expected HTTP probe to succeed")
+	}
+}
+
+func TestRunProbeHTTPBadStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	h := &Helper{}
+	ok, err := h.runProbe(Probe{Type: ProbeTypeHTTP, Address: strings.TrimPrefix(server.URL, "http://")})
+	if ok {
+		t.Errorf("expected HTTP probe returning 503 to fail")
+	}
+	probeErr, isProbeErr := err.(*ProbeError)
+	if !isProbeErr {
+		t.Fatalf("expected a *ProbeError, got %T: %v", err, err)
+	}
+	if probeErr.Reason != ProbeReasonBadStatus {
+		t.Errorf("ProbeError.Reason = %v, want %v", probeErr.Reason, ProbeReasonBadStatus)
+	}
+}
+
+func TestRunProbeUnknownType(t *testing.T) {
+	h := &Helper{}
+	if _, err := h.runProbe(Probe{Type: "bogus"}); err == nil {
+		t.Errorf("expected an error for an unknown probe type")
+	}
+}
+
+func alwaysRunning() (bool, bool, error) { return true, true, nil }
+
+func TestWaitForHealthyReadyOnFirstProbe(t *testing.T) {
+	probeCalls := 0
+	probe := func() (bool, error) {
+		probeCalls++
+		return true, nil
+	}
+
+	err := waitForHealthy("c1", alwaysRunning, probe, time.Second, 10*time.Millisecond, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if probeCalls != 1 {
+		t.Errorf("expected exactly 1 probe call, got %d", probeCalls)
+	}
+}
+
+func TestWaitForHealthyContainerExited(t *testing.T) {
+	checkState := func() (bool, bool, error) { return true, false, nil }
+	probe := func() (bool, error) { return false, nil }
+
+	err := waitForHealthy("c1", checkState, probe, time.Second, 10*time.Millisecond, 100*time.Millisecond)
+	probeErr, ok := err.(*ProbeError)
+	if !ok {
+		t.Fatalf("expected a *ProbeError, got %T: %v", err, err)
+	}
+	if probeErr.Reason != ProbeReasonContainerExited {
+		t.Errorf("ProbeError.Reason = %v, want %v", probeErr.Reason, ProbeReasonContainerExited)
+	}
+}
+
+func TestWaitForHealthyCheckStateError(t *testing.T) {
+	wantErr := errors.New("inspect failed")
+	checkState := func() (bool, bool, error) { return false, false, wantErr }
+	probe := func() (bool, error) { return false, nil }
+
+	err := waitForHealthy("c1", checkState, probe, time.Second, 10*time.Millisecond, 100*time.Millisecond)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+// TestWaitForHealthyAttemptsProbeNearDeadline guards against a past bug
+// where, once the next backoff step would cross the deadline,
+// waitForHealthy returned ProbeReasonTimeout without ever sleeping the
+// remaining time and trying once more. With an initial backoff much larger
+// than the timeout, a buggy implementation would give up after exactly one
+// probe call; the fix must clamp the sleep to what's left and probe again.
+func TestWaitForHealthyAttemptsProbeNearDeadline(t *testing.T) {
+	probeCalls := 0
+	probe := func() (bool, error) {
+		probeCalls++
+		return false, nil
+	}
+
+	err := waitForHealthy("c1", alwaysRunning, probe, 50*time.Millisecond, 500*time.Millisecond, 500*time.Millisecond)
+	probeErr, ok := err.(*ProbeError)
+	if !ok {
+		t.Fatalf("expected a *ProbeError, got %T: %v", err, err)
+	}
+	if probeErr.Reason != ProbeReasonTimeout {
+		t.Errorf("ProbeError.Reason = %v, want %v", probeErr.Reason, ProbeReasonTimeout)
+	}
+	if probeCalls < 2 {
+		t.Errorf("expected at least 2 probe attempts (one more near the deadline), got %d", probeCalls)
+	}
+}
@@ -1,6 +1,7 @@
 package dockerhelper
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -22,6 +23,15 @@ const openShiftInsecureCIDR = "172.30.0.0/16"
 // Helper provides utility functions to help with Docker
 type Helper struct {
 	client *docker.Client
+
+	// AuthResolver resolves registry credentials to use when pulling
+	// images. If nil, only the credentials passed to CheckAndPullWithContext
+	// are used (none for CheckAndPull and PullImages).
+	AuthResolver AuthResolver
+
+	// RegistryMirrors, if set, are tried in order before falling back to
+	// the registry embedded in the image reference.
+	RegistryMirrors []string
 }
 
 // NewHelper creates a new Helper
@@ -105,8 +115,22 @@ func (h *Helper) Version() (*semver.Version, error) {
 	return &dockerVersion, nil
 }
 
-// CheckAndPull checks whether a Docker image exists. If not, it pulls it.
+// CheckAndPull checks whether a Docker image exists. If not, it pulls it,
+// resolving authentication via h.AuthResolver and trying h.RegistryMirrors
+// (if any) before the canonical registry.
 func (h *Helper) CheckAndPull(image string, out io.Writer) error {
+	return h.CheckAndPullWithContext(context.Background(), image, docker.AuthConfiguration{}, out)
+}
+
+// CheckAndPullWithContext checks whether a Docker image exists. If not, it
+// pulls it, trying h.RegistryMirrors (if any) before the canonical registry
+// embedded in image. Each candidate's credentials are looked up separately
+// via h.AuthResolver, since a mirror is a different registry host from the
+// canonical one and typically needs its own credentials; auth is used as a
+// fallback for candidates that h.AuthResolver has no credentials for (or
+// when h.AuthResolver is nil). The pull is aborted if ctx is cancelled
+// before it completes.
+func (h *Helper) CheckAndPullWithContext(ctx context.Context, image string, auth docker.AuthConfiguration, out io.Writer) error {
 	glog.V(5).Infof("Inspecting Docker image %q", image)
 	imageMeta, err := h.client.InspectImage(image)
 	if err == nil {
@@ -117,7 +141,81 @@ func (h *Helper) CheckAndPull(image string, out io.Writer) error {
 		return starterrors.NewError("unexpected error inspecting image %s", image).WithCause(err)
 	}
 	glog.V(5).Infof("Image %q not found. Pulling", image)
-	fmt.Fprintf(out, "Pulling image %s\n", image)
+
+	var lastErr error
+	for _, candidate := range h.pullCandidates(image) {
+		if err := ctx.Err(); err != nil {
+			return starterrors.NewError("pull of image %s was cancelled", image).WithCause(err)
+		}
+		candidateAuth, err := h.resolveCandidateAuth(candidate, auth)
+		if err != nil {
+			glog.V(2).Infof("Error resolving credentials for %s: %v", candidate, err)
+			lastErr = err
+			continue
+		}
+		fmt.Fprintf(out, "Pulling image %s\n", candidate)
+		if err := h.pullImage(ctx, candidate, candidateAuth, out); err != nil {
+			glog.V(2).Infof("Error pulling %s: %v", candidate, err)
+			lastErr = err
+			continue
+		}
+		// Mirrors publish images under their own repository path, so a
+		// successful pull from a mirror still needs to be tagged under
+		// the name the caller asked for.
+		if candidate != image {
+			if err := h.client.TagImage(candidate, docker.TagImageOptions{Repo: image, Force: true}); err != nil {
+				return starterrors.NewError("error tagging mirrored image %s as %s", candidate, image).WithCause(err)
+			}
+		}
+		fmt.Fprintf(out, "Image pull comlete\n")
+		return nil
+	}
+	return starterrors.NewError("error pulling Docker image %s", image).WithCause(lastErr)
+}
+
+// pullCandidates returns the list of image references to attempt, in order:
+// one per registry mirror, followed by the original image reference.
+func (h *Helper) pullCandidates(image string) []string {
+	candidates := make([]string, 0, len(h.RegistryMirrors)+1)
+	for _, mirror := range h.RegistryMirrors {
+		mirrored, err := withRegistryHost(image, mirror)
+		if err != nil {
+			glog.V(2).Infof("Cannot rewrite %s for mirror %s: %v", image, mirror, err)
+			continue
+		}
+		candidates = append(candidates, mirrored)
+	}
+	return append(candidates, image)
+}
+
+// resolveAuth looks up credentials for the registry hosting image via
+// h.AuthResolver. A nil AuthResolver yields an empty AuthConfiguration.
+func (h *Helper) resolveAuth(image string) (docker.AuthConfiguration, error) {
+	if h.AuthResolver == nil {
+		return docker.AuthConfiguration{}, nil
+	}
+	auth, err := h.AuthResolver.ResolveAuthentication(registryHost(image))
+	if err != nil {
+		return docker.AuthConfiguration{}, starterrors.NewError("error resolving credentials for image %s", image).WithCause(err)
+	}
+	return auth, nil
+}
+
+// resolveCandidateAuth looks up credentials for the registry hosting
+// candidate via h.AuthResolver, falling back to fallback when h.AuthResolver
+// is nil or has no credentials for that registry.
+func (h *Helper) resolveCandidateAuth(candidate string, fallback docker.AuthConfiguration) (docker.AuthConfiguration, error) {
+	auth, err := h.resolveAuth(candidate)
+	if err != nil {
+		return docker.AuthConfiguration{}, err
+	}
+	if auth != (docker.AuthConfiguration{}) {
+		return auth, nil
+	}
+	return fallback, nil
+}
+
+func (h *Helper) pullImage(ctx context.Context, image string, auth docker.AuthConfiguration, out io.Writer) error {
 	extracting := false
 	var outputStream io.Writer
 	writeProgress := func(r *pullprogress.ProgressReport) {
@@ -145,16 +243,53 @@ func (h *Helper) CheckAndPull(image string, out io.Writer) error {
 	} else {
 		outputStream = out
 	}
-	err = h.client.PullImage(docker.PullImageOptions{
-		Repository:    image,
-		RawJSONStream: bool(!glog.V(5)),
-		OutputStream:  outputStream,
-	}, docker.AuthConfiguration{})
-	if err != nil {
-		return starterrors.NewError("error pulling Docker image %s", image).WithCause(err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.client.PullImage(docker.PullImageOptions{
+			Repository:    image,
+			RawJSONStream: bool(!glog.V(5)),
+			OutputStream:  outputStream,
+			Context:       ctx,
+		}, auth)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
 	}
-	fmt.Fprintf(out, "Image pull comlete\n")
-	return nil
+}
+
+// registryHost returns the registry host portion of an image reference
+// (e.g. "registry.example.com:5000/foo/bar:tag" -> "registry.example.com:5000"),
+// defaulting to "docker.io" for unqualified references such as "centos:7".
+func registryHost(image string) string {
+	name := image
+	if at := strings.Index(name, "@"); at != -1 {
+		name = name[:at]
+	}
+	remainder := name
+	if slash := strings.Index(remainder, "/"); slash != -1 {
+		candidate := remainder[:slash]
+		if strings.ContainsAny(candidate, ".:") || candidate == "localhost" {
+			return candidate
+		}
+	}
+	return "docker.io"
+}
+
+// withRegistryHost rewrites the registry host portion of an image reference
+// to host, preserving the repository path, tag, and digest.
+func withRegistryHost(image, host string) (string, error) {
+	if len(host) == 0 {
+		return "", errors.New("empty registry host")
+	}
+	current := registryHost(image)
+	if current == "docker.io" && !strings.HasPrefix(image, "docker.io/") {
+		return host + "/" + image, nil
+	}
+	return host + strings.TrimPrefix(image, current), nil
 }
 
 // GetContainerState returns whether a container exists and if it does whether it's running
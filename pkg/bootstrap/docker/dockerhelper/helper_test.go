@@ -0,0 +1,94 @@
+package dockerhelper
+
+import (
+	"reflect"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func TestRegistryHost(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{"centos:7", "docker.io"},
+		{"openshift/origin", "docker.io"},
+		{"registry.example.com:5000/openshift/origin:latest", "registry.example.com:5000"},
+		{"registry.example.com/openshift/origin@sha256:abcd", "registry.example.com"},
+		{"localhost:5000/foo", "localhost:5000"},
+	}
+	for _, test := range tests {
+		if got := registryHost(test.image); got != test.expected {
+			t.Errorf("registryHost(%q) = %q, want %q", test.image, got, test.expected)
+		}
+	}
+}
+
+func TestWithRegistryHost(t *testing.T) {
+	tests := []struct {
+		image    string
+		host     string
+		expected string
+	}{
+		{"centos:7", "mirror.example.com", "mirror.example.com/centos:7"},
+		{"openshift/origin", "mirror.example.com", "mirror.example.com/openshift/origin"},
+		{"registry.example.com:5000/openshift/origin:latest", "mirror.example.com", "mirror.example.com/openshift/origin:latest"},
+	}
+	for _, test := range tests {
+		got, err := withRegistryHost(test.image, test.host)
+		if err != nil {
+			t.Errorf("withRegistryHost(%q, %q) returned error: %v", test.image, test.host, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("withRegistryHost(%q, %q) = %q, want %q", test.image, test.host, got, test.expected)
+		}
+	}
+
+	if _, err := withRegistryHost("centos:7", ""); err == nil {
+		t.Errorf("expected error for empty registry host")
+	}
+}
+
+func TestPullCandidates(t *testing.T) {
+	h := &Helper{RegistryMirrors: []string{"mirror1.example.com", "mirror2.example.com:5000"}}
+	got := h.pullCandidates("registry.example.com/openshift/origin:latest")
+	expected := []string{
+		"mirror1.example.com/openshift/origin:latest",
+		"mirror2.example.com:5000/openshift/origin:latest",
+		"registry.example.com/openshift/origin:latest",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("pullCandidates() = %v, want %v", got, expected)
+	}
+}
+
+func TestResolveCandidateAuthPerCandidate(t *testing.T) {
+	creds := map[string]docker.AuthConfiguration{
+		"mirror.example.com": {Username: "mirror-user", Password: "mirror-pass"},
+	}
+	h := &Helper{
+		AuthResolver: AuthResolverFunc(func(registry string) (docker.AuthConfiguration, error) {
+			return creds[registry], nil
+		}),
+	}
+
+	fallback := docker.AuthConfiguration{Username: "canonical-user"}
+
+	got, err := h.resolveCandidateAuth("mirror.example.com/foo:latest", fallback)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != creds["mirror.example.com"] {
+		t.Errorf("resolveCandidateAuth() = %+v, want mirror credentials %+v", got, creds["mirror.example.com"])
+	}
+
+	got, err = h.resolveCandidateAuth("registry.example.com/foo:latest", fallback)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != fallback {
+		t.Errorf("resolveCandidateAuth() = %+v, want fallback %+v when resolver has no credentials", got, fallback)
+	}
+}
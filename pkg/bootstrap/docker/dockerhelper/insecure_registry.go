@@ -0,0 +1,212 @@
+package dockerhelper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/golang/glog"
+
+	starterrors "github.com/openshift/origin/pkg/bootstrap/docker/errors"
+)
+
+const linuxDaemonConfigPath = "/etc/docker/daemon.json"
+
+// daemonConfig is a partial view of the Docker daemon configuration file,
+// preserving unknown keys so a rewrite doesn't clobber unrelated settings.
+type daemonConfig struct {
+	raw map[string]json.RawMessage
+}
+
+func newDaemonConfig(data []byte) (*daemonConfig, error) {
+	cfg := &daemonConfig{raw: map[string]json.RawMessage{}}
+	if len(data) == 0 {
+		return cfg, nil
+	}
+	if err := json.Unmarshal(data, &cfg.raw); err != nil {
+		return nil, fmt.Errorf("error parsing daemon config: %v", err)
+	}
+	return cfg, nil
+}
+
+func (c *daemonConfig) insecureRegistries() ([]string, error) {
+	raw, ok := c.raw["insecure-registries"]
+	if !ok {
+		return nil, nil
+	}
+	var registries []string
+	if err := json.Unmarshal(raw, &registries); err != nil {
+		return nil, fmt.Errorf("error parsing insecure-registries: %v", err)
+	}
+	return registries, nil
+}
+
+func (c *daemonConfig) setInsecureRegistries(registries []string) error {
+	raw, err := json.Marshal(registries)
+	if err != nil {
+		return err
+	}
+	c.raw["insecure-registries"] = raw
+	return nil
+}
+
+func (c *daemonConfig) marshalIndent() ([]byte, error) {
+	return json.MarshalIndent(c.raw, "", "  ")
+}
+
+// mergeInsecureRegistry adds cidr to the insecure-registries list found in
+// the daemon config file contents original (which may be empty, meaning no
+// config file exists yet), returning the rewritten contents. changed is
+// false, and updated is nil, if cidr was already present.
+func mergeInsecureRegistry(original []byte, cidr string) (updated []byte, changed bool, err error) {
+	cfg, err := newDaemonConfig(original)
+	if err != nil {
+		return nil, false, err
+	}
+	registries, err := cfg.insecureRegistries()
+	if err != nil {
+		return nil, false, err
+	}
+	if hasCIDR(cidr, registries) {
+		return nil, false, nil
+	}
+	if err := cfg.setInsecureRegistries(append(registries, cidr)); err != nil {
+		return nil, false, err
+	}
+	updated, err = cfg.marshalIndent()
+	if err != nil {
+		return nil, false, err
+	}
+	return updated, true, nil
+}
+
+// daemonConfigPath locates the Docker daemon's configuration file, using
+// fields reported by the daemon's Info() call:
+//
+//   - a daemon-reported OperatingSystem of "Docker Desktop" means the
+//     daemon itself runs inside a VM, so its own daemon.json isn't the file
+//     users edit; Docker Desktop instead keeps a per-host settings file that
+//     the daemon has no path for, so its location is derived from the
+//     client's own OS instead.
+//   - otherwise, a non-empty DockerRootDir confirms this is a native Linux
+//     daemon, whose config always lives at /etc/docker/daemon.json
+//     regardless of where DockerRootDir itself points.
+func daemonConfigPath(info map[string]interface{}) (string, error) {
+	operatingSystem, _ := info["OperatingSystem"].(string)
+	dockerRootDir, _ := info["DockerRootDir"].(string)
+
+	if strings.Contains(operatingSystem, "Docker Desktop") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		switch runtime.GOOS {
+		case "darwin":
+			return filepath.Join(home, "Library", "Group Containers", "group.com.docker", "settings.json"), nil
+		case "windows":
+			return filepath.Join(home, "AppData", "Roaming", "Docker", "settings.json"), nil
+		default:
+			return "", fmt.Errorf("daemon reports Docker Desktop (OperatingSystem %q) but client OS %q has no known settings file", operatingSystem, runtime.GOOS)
+		}
+	}
+
+	if len(dockerRootDir) == 0 {
+		return "", fmt.Errorf("cannot determine Docker daemon config location: daemon reported no DockerRootDir (OperatingSystem %q)", operatingSystem)
+	}
+	return linuxDaemonConfigPath, nil
+}
+
+// EnsureInsecureRegistry ensures cidr is present in the daemon's
+// insecure-registries list, rewriting the daemon config file in place if it
+// is missing. The write is atomic: the new contents are written to a temp
+// file in the same directory and renamed into place, with the previous
+// contents backed up to a ".bak" file alongside it. It returns whether a
+// change was made, which implies the caller must restart the Docker daemon
+// for the change to take effect.
+func (h *Helper) EnsureInsecureRegistry(cidr string) (changed bool, err error) {
+	changed, _, err = h.ensureInsecureRegistry(cidr, false)
+	return changed, err
+}
+
+// DiffInsecureRegistry reports whether cidr is missing from the daemon's
+// insecure-registries list and, if so, returns the JSON diff that
+// EnsureInsecureRegistry would write, without modifying anything on disk.
+func (h *Helper) DiffInsecureRegistry(cidr string) (changed bool, diff string, err error) {
+	return h.ensureInsecureRegistry(cidr, true)
+}
+
+func (h *Helper) ensureInsecureRegistry(cidr string, dryRun bool) (changed bool, diff string, err error) {
+	env, err := h.client.Info()
+	if err != nil {
+		return false, "", starterrors.NewError("could not retrieve Docker info").WithCause(err)
+	}
+	info := map[string]interface{}{
+		"OperatingSystem": env.Get("OperatingSystem"),
+		"DockerRootDir":   env.Get("DockerRootDir"),
+	}
+
+	path, err := daemonConfigPath(info)
+	if err != nil {
+		return false, "", starterrors.NewError("could not locate Docker daemon config").WithCause(err)
+	}
+
+	original, readErr := ioutil.ReadFile(path)
+	if readErr != nil && !os.IsNotExist(readErr) {
+		return false, "", starterrors.NewError("could not read Docker daemon config %s", path).WithCause(readErr)
+	}
+
+	updated, changed, err := mergeInsecureRegistry(original, cidr)
+	if err != nil {
+		return false, "", starterrors.NewError("could not update Docker daemon config %s", path).WithCause(err)
+	}
+	if !changed {
+		glog.V(5).Infof("%s already contains insecure registry %q", path, cidr)
+		return false, "", nil
+	}
+
+	if dryRun {
+		return true, fmt.Sprintf("--- %s\n+++ %s\n-%s\n+%s\n", path, path, original, updated), nil
+	}
+
+	if len(original) > 0 {
+		if err := ioutil.WriteFile(path+".bak", original, 0644); err != nil {
+			return false, "", starterrors.NewError("could not back up Docker daemon config %s", path).WithCause(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, "", starterrors.NewError("could not create directory for Docker daemon config %s", path).WithCause(err)
+	}
+	if err := writeFileAtomically(path, updated, 0644); err != nil {
+		return false, "", starterrors.NewError("could not write Docker daemon config %s", path).WithCause(err)
+	}
+	glog.V(2).Infof("Added insecure registry %q to %s; a Docker daemon restart is required", cidr, path)
+	return true, "", nil
+}
+
+// writeFileAtomically writes data to a temp file in the same directory as
+// path and renames it into place, so a crash or power loss never leaves
+// path truncated or partially written.
+func writeFileAtomically(path string, data []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once the rename below succeeds
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,120 @@
+package dockerhelper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeInsecureRegistryNoExistingFile(t *testing.T) {
+	updated, changed, err := mergeInsecureRegistry(nil, "172.30.0.0/16")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !changed {
+		t.Fatalf("expected changed = true")
+	}
+	cfg, err := newDaemonConfig(updated)
+	if err != nil {
+		t.Fatalf("unexpected error parsing merged config: %v", err)
+	}
+	registries, err := cfg.insecureRegistries()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasCIDR("172.30.0.0/16", registries) {
+		t.Errorf("expected merged config to contain the new CIDR, got %v", registries)
+	}
+}
+
+func TestMergeInsecureRegistryAlreadyPresent(t *testing.T) {
+	original := []byte(`{"insecure-registries": ["172.30.0.0/16"], "debug": true}`)
+	updated, changed, err := mergeInsecureRegistry(original, "172.30.0.0/16")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if changed {
+		t.Errorf("expected changed = false when CIDR is already present")
+	}
+	if updated != nil {
+		t.Errorf("expected nil updated contents when nothing changed")
+	}
+}
+
+func TestMergeInsecureRegistryPreservesUnrelatedKeys(t *testing.T) {
+	original := []byte(`{"insecure-registries": ["10.0.0.0/8"], "debug": true}`)
+	updated, changed, err := mergeInsecureRegistry(original, "172.30.0.0/16")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !changed {
+		t.Fatalf("expected changed = true")
+	}
+	cfg, err := newDaemonConfig(updated)
+	if err != nil {
+		t.Fatalf("unexpected error parsing merged config: %v", err)
+	}
+	if _, ok := cfg.raw["debug"]; !ok {
+		t.Errorf("expected unrelated key %q to survive the merge, got %s", "debug", updated)
+	}
+	registries, err := cfg.insecureRegistries()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasCIDR("10.0.0.0/8", registries) || !hasCIDR("172.30.0.0/16", registries) {
+		t.Errorf("expected merged config to contain both CIDRs, got %v", registries)
+	}
+}
+
+func TestDaemonConfigPathLinux(t *testing.T) {
+	path, err := daemonConfigPath(map[string]interface{}{
+		"OperatingSystem": "Fedora 24 (Workstation Edition)",
+		"DockerRootDir":   "/var/lib/docker",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != linuxDaemonConfigPath {
+		t.Errorf("daemonConfigPath() = %q, want %q", path, linuxDaemonConfigPath)
+	}
+}
+
+func TestDaemonConfigPathMissingDockerRootDir(t *testing.T) {
+	if _, err := daemonConfigPath(map[string]interface{}{"OperatingSystem": "Fedora 24"}); err == nil {
+		t.Errorf("expected an error when the daemon reports no DockerRootDir")
+	}
+}
+
+func TestWriteFileAtomicallyReplacesContentsAndLeavesNoTempFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dockerhelper-atomic-write")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "daemon.json")
+	if err := ioutil.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := writeFileAtomically(path, []byte("updated"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(contents) != "updated" {
+		t.Errorf("file contents = %q, want %q", contents, "updated")
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected exactly one file in %s after an atomic write, found %d", dir, len(entries))
+	}
+}
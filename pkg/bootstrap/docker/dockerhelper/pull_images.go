@@ -0,0 +1,254 @@
+package dockerhelper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+
+	starterrors "github.com/openshift/origin/pkg/bootstrap/docker/errors"
+)
+
+// PullPhase describes the stage of an image pull that a ProgressEvent
+// reports on.
+type PullPhase string
+
+const (
+	PullPhaseWaiting     PullPhase = "Waiting"
+	PullPhaseDownloading PullPhase = "Downloading"
+	PullPhaseExtracting  PullPhase = "Extracting"
+	PullPhaseComplete    PullPhase = "Complete"
+	PullPhaseError       PullPhase = "Error"
+)
+
+// ProgressEvent reports progress for a single layer of a single image pull.
+// BytesDone and BytesTotal are the layer's actual byte counts as reported
+// by the Docker daemon, not a percentage.
+type ProgressEvent struct {
+	Image      string
+	Layer      string
+	Phase      PullPhase
+	BytesDone  int64
+	BytesTotal int64
+	Time       time.Time
+}
+
+// aggregatePullError collects the per-image errors encountered by
+// PullImages.
+type aggregatePullError struct {
+	Errors map[string]error
+}
+
+func (e *aggregatePullError) Error() string {
+	msg := fmt.Sprintf("failed to pull %d image(s):", len(e.Errors))
+	for image, err := range e.Errors {
+		msg += fmt.Sprintf("\n  %s: %v", image, err)
+	}
+	return msg
+}
+
+// PullImages pulls the given images, deduplicating repeated references,
+// using a worker pool bounded by concurrency (treated as 1 if lower).
+// Progress is reported on events as the pulls proceed; PullImages closes
+// events before returning. events may be nil if the caller doesn't want
+// progress events, in which case progress reporting is simply skipped. If
+// one or more images fail to pull, the rest still run to completion and the
+// failures are returned together as an *aggregatePullError.
+func (h *Helper) PullImages(images []string, concurrency int, events chan<- ProgressEvent) error {
+	if events != nil {
+		defer close(events)
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	unique := dedupeImages(images)
+
+	work := make(chan string)
+	errs := map[string]error{}
+	var errsMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for image := range work {
+				if err := h.pullImageWithEvents(image, events); err != nil {
+					errsMu.Lock()
+					errs[image] = err
+					errsMu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, image := range unique {
+		work <- image
+	}
+	close(work)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &aggregatePullError{Errors: errs}
+	}
+	return nil
+}
+
+// sendEvent sends event on events, a no-op if events is nil so callers that
+// pass a nil channel (the natural "I don't want progress events" zero
+// value) aren't required to provide and drain one.
+func sendEvent(events chan<- ProgressEvent, event ProgressEvent) {
+	if events == nil {
+		return
+	}
+	events <- event
+}
+
+// dedupeImages returns images with repeated references removed, preserving
+// the order of first occurrence.
+func dedupeImages(images []string) []string {
+	seen := map[string]bool{}
+	unique := make([]string, 0, len(images))
+	for _, image := range images {
+		if seen[image] {
+			continue
+		}
+		seen[image] = true
+		unique = append(unique, image)
+	}
+	return unique
+}
+
+// pullImageWithEvents pulls a single image, trying h.pullCandidates(image)
+// in order and resolving credentials separately for each candidate (a
+// mirror is a different registry host from the canonical one and typically
+// needs its own credentials). Layer-level progress is reported on events as
+// real byte counts decoded from the daemon's pull status stream.
+func (h *Helper) pullImageWithEvents(image string, events chan<- ProgressEvent) error {
+	glog.V(5).Infof("Inspecting Docker image %q", image)
+	if _, err := h.client.InspectImage(image); err == nil {
+		sendEvent(events, ProgressEvent{Image: image, Phase: PullPhaseComplete, Time: pullEventTime()})
+		return nil
+	}
+
+	var lastErr error
+	for _, candidate := range h.pullCandidates(image) {
+		auth, err := h.resolveCandidateAuth(candidate, docker.AuthConfiguration{})
+		if err != nil {
+			glog.V(2).Infof("Error resolving credentials for %s: %v", candidate, err)
+			lastErr = err
+			continue
+		}
+
+		writer := &pullEventWriter{image: image, events: events}
+		err = h.client.PullImage(docker.PullImageOptions{
+			Repository:    candidate,
+			RawJSONStream: true,
+			OutputStream:  writer,
+		}, auth)
+		if err != nil {
+			glog.V(2).Infof("Error pulling %s: %v", candidate, err)
+			lastErr = err
+			continue
+		}
+
+		if candidate != image {
+			if err := h.client.TagImage(candidate, docker.TagImageOptions{Repo: image, Force: true}); err != nil {
+				return starterrors.NewError("error tagging mirrored image %s as %s", candidate, image).WithCause(err)
+			}
+		}
+		sendEvent(events, ProgressEvent{Image: image, Phase: PullPhaseComplete, Time: pullEventTime()})
+		return nil
+	}
+	sendEvent(events, ProgressEvent{Image: image, Phase: PullPhaseError, Time: pullEventTime()})
+	return starterrors.NewError("error pulling Docker image %s", image).WithCause(lastErr)
+}
+
+// pullStatusMessage is the subset of the daemon's newline-delimited JSON
+// pull status stream (docker.PullImageOptions{RawJSONStream: true}) that we
+// care about.
+type pullStatusMessage struct {
+	Status         string `json:"status"`
+	ID             string `json:"id"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+}
+
+// mapPullStatusToPhase maps a daemon pull status string to a PullPhase. ok
+// is false, and no ProgressEvent should be produced, for any status not
+// listed below, such as the final "Digest: ..." summary line or the
+// per-layer "Download complete" line.
+func mapPullStatusToPhase(status string) (phase PullPhase, ok bool) {
+	switch status {
+	case "Waiting", "Pulling fs layer":
+		return PullPhaseWaiting, true
+	case "Downloading":
+		return PullPhaseDownloading, true
+	case "Extracting", "Verifying Checksum":
+		return PullPhaseExtracting, true
+	case "Pull complete", "Already exists":
+		return PullPhaseComplete, true
+	default:
+		return "", false
+	}
+}
+
+// pullEventWriter is an io.Writer that decodes the newline-delimited JSON
+// status stream the Docker daemon writes for a RawJSONStream pull and turns
+// each per-layer status line into a ProgressEvent.
+type pullEventWriter struct {
+	image  string
+	events chan<- ProgressEvent
+	buf    []byte
+}
+
+func (w *pullEventWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		line := w.buf[:idx]
+		w.buf = w.buf[idx+1:]
+		w.decodeLine(line)
+	}
+	return len(p), nil
+}
+
+func (w *pullEventWriter) decodeLine(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	var msg pullStatusMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		glog.V(5).Infof("Could not parse pull status line for %s: %q: %v", w.image, line, err)
+		return
+	}
+	phase, ok := mapPullStatusToPhase(msg.Status)
+	if !ok {
+		return
+	}
+	sendEvent(w.events, ProgressEvent{
+		Image:      w.image,
+		Layer:      msg.ID,
+		Phase:      phase,
+		BytesDone:  msg.ProgressDetail.Current,
+		BytesTotal: msg.ProgressDetail.Total,
+		Time:       pullEventTime(),
+	})
+}
+
+// pullEventTime returns the timestamp to stamp on a ProgressEvent. It is a
+// var so tests can make pull event timestamps deterministic.
+var pullEventTime = func() time.Time {
+	return time.Now()
+}
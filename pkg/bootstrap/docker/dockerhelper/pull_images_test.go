@@ -0,0 +1,117 @@
+package dockerhelper
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDedupeImages(t *testing.T) {
+	got := dedupeImages([]string{"a", "b", "a", "c", "b"})
+	want := []string{"a", "b", "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupeImages() = %v, want %v", got, want)
+	}
+}
+
+func TestMapPullStatusToPhase(t *testing.T) {
+	tests := []struct {
+		status    string
+		wantPhase PullPhase
+		wantOK    bool
+	}{
+		{"Pulling fs layer", PullPhaseWaiting, true},
+		{"Downloading", PullPhaseDownloading, true},
+		{"Extracting", PullPhaseExtracting, true},
+		{"Pull complete", PullPhaseComplete, true},
+		{"Already exists", PullPhaseComplete, true},
+		{"Digest: sha256:abcd", "", false},
+	}
+	for _, test := range tests {
+		phase, ok := mapPullStatusToPhase(test.status)
+		if phase != test.wantPhase || ok != test.wantOK {
+			t.Errorf("mapPullStatusToPhase(%q) = (%q, %v), want (%q, %v)", test.status, phase, ok, test.wantPhase, test.wantOK)
+		}
+	}
+}
+
+func TestPullEventWriterDecodesByteCounts(t *testing.T) {
+	events := make(chan ProgressEvent, 10)
+	w := &pullEventWriter{image: "openshift/origin:latest", events: events}
+
+	line := `{"status":"Downloading","id":"abcd1234","progressDetail":{"current":512,"total":1024}}` + "\n"
+	if _, err := w.Write([]byte(line)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Image != "openshift/origin:latest" || event.Layer != "abcd1234" || event.Phase != PullPhaseDownloading {
+			t.Errorf("unexpected event: %+v", event)
+		}
+		if event.BytesDone != 512 || event.BytesTotal != 1024 {
+			t.Errorf("event bytes = (%d, %d), want (512, 1024)", event.BytesDone, event.BytesTotal)
+		}
+	default:
+		t.Fatalf("expected a ProgressEvent to be emitted")
+	}
+}
+
+func TestPullEventWriterBuffersPartialLines(t *testing.T) {
+	events := make(chan ProgressEvent, 10)
+	w := &pullEventWriter{image: "openshift/origin:latest", events: events}
+
+	if _, err := w.Write([]byte(`{"status":"Pull complete","id":"a`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("did not expect an event before the line was complete, got %+v", event)
+	default:
+	}
+
+	if _, err := w.Write([]byte("bcd\"}\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case event := <-events:
+		if event.Layer != "abcd" || event.Phase != PullPhaseComplete {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	default:
+		t.Fatalf("expected a ProgressEvent once the line was completed")
+	}
+}
+
+func TestPullEventWriterIgnoresUnrecognizedStatus(t *testing.T) {
+	events := make(chan ProgressEvent, 10)
+	w := &pullEventWriter{image: "openshift/origin:latest", events: events}
+
+	if _, err := w.Write([]byte(`{"status":"Digest: sha256:abcd"}` + "\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("did not expect an event for an unrecognized status, got %+v", event)
+	default:
+	}
+}
+
+func TestSendEventNilChannelDoesNotBlockOrPanic(t *testing.T) {
+	sendEvent(nil, ProgressEvent{Image: "centos:7", Phase: PullPhaseComplete})
+}
+
+func TestPullEventWriterNilEventsChannelDoesNotPanic(t *testing.T) {
+	w := &pullEventWriter{image: "openshift/origin:latest", events: nil}
+	if _, err := w.Write([]byte(`{"status":"Pull complete","id":"abcd"}` + "\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAggregatePullErrorMessage(t *testing.T) {
+	err := &aggregatePullError{Errors: map[string]error{"centos:7": errors.New("boom")}}
+	msg := err.Error()
+	if msg == "" {
+		t.Fatalf("expected a non-empty error message")
+	}
+}